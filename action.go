@@ -0,0 +1,195 @@
+package main
+
+// actionInput describes one value an Action needs collected from the user
+// before BuildArgs can run: e.g. the address/ID pair `import` needs, or the
+// typed "destroy" confirmation.
+type actionInput struct {
+	Key         string
+	Label       string
+	Placeholder string
+	Required    bool
+	// Confirm, when set, is the exact text the user must type for this
+	// input to be accepted (used for destroy's typed confirmation).
+	Confirm string
+}
+
+// Action is one entry in the action list: a terraform subcommand plus
+// everything tfz needs to know to decide whether it applies to the current
+// selection, what extra input to collect, and how to turn all of that into
+// a terraform argv.
+type Action interface {
+	Name() string
+	// Applicable reports whether this action makes sense given the
+	// targets the user selected (selectedTargets() shape: nil/empty means
+	// "all").
+	Applicable(selected []string) bool
+	Inputs() []actionInput
+	BuildArgs(selected []string, inputs map[string]string) []string
+}
+
+func targetFlags(selected []string) []string {
+	flags := make([]string, 0, len(selected))
+	for _, t := range selected {
+		flags = append(flags, "-target="+t)
+	}
+	return flags
+}
+
+// singleResourceAction is embedded by actions (taint, untaint, state rm,
+// state mv, import) that only make sense against exactly one specifically-selected
+// target, never "all" or a multi-select.
+type singleResourceAction struct{}
+
+func (singleResourceAction) Applicable(selected []string) bool {
+	return len(selected) == 1
+}
+
+// broadAction is embedded by actions that run the same whether "all" or a
+// subset of targets is selected.
+type broadAction struct{}
+
+func (broadAction) Applicable(_ []string) bool { return true }
+func (broadAction) Inputs() []actionInput      { return nil }
+
+type planAction struct{ broadAction }
+
+func (planAction) Name() string { return "plan" }
+func (planAction) BuildArgs(selected []string, _ map[string]string) []string {
+	return append([]string{"plan"}, targetFlags(selected)...)
+}
+
+type applyAction struct{ broadAction }
+
+func (applyAction) Name() string { return "apply" }
+func (applyAction) Inputs() []actionInput {
+	return []actionInput{
+		{Key: "varFile", Label: "-var-file (optional, blank to skip)", Placeholder: "terraform.tfvars"},
+		{Key: "autoApprove", Label: "-auto-approve? (y/N)", Placeholder: "N"},
+	}
+}
+func (applyAction) BuildArgs(selected []string, inputs map[string]string) []string {
+	args := []string{"apply"}
+	if inputs["autoApprove"] == "y" || inputs["autoApprove"] == "Y" {
+		args = append(args, "-auto-approve")
+	}
+	if v := inputs["varFile"]; v != "" {
+		args = append(args, "-var-file="+v)
+	}
+	return append(args, targetFlags(selected)...)
+}
+
+type destroyAction struct{ broadAction }
+
+func (destroyAction) Name() string { return "destroy" }
+func (destroyAction) Inputs() []actionInput {
+	return []actionInput{
+		{Key: "confirm", Label: `Type "destroy" to confirm`, Required: true, Confirm: "destroy"},
+	}
+}
+func (destroyAction) BuildArgs(selected []string, _ map[string]string) []string {
+	return append([]string{"destroy"}, targetFlags(selected)...)
+}
+
+type refreshAction struct{ broadAction }
+
+func (refreshAction) Name() string { return "refresh" }
+func (refreshAction) BuildArgs(selected []string, _ map[string]string) []string {
+	return append([]string{"refresh"}, targetFlags(selected)...)
+}
+
+type validateAction struct{ broadAction }
+
+func (validateAction) Name() string { return "validate" }
+func (validateAction) BuildArgs(_ []string, _ map[string]string) []string {
+	return []string{"validate"}
+}
+
+type fmtAction struct{ broadAction }
+
+func (fmtAction) Name() string { return "fmt" }
+func (fmtAction) BuildArgs(_ []string, _ map[string]string) []string {
+	return []string{"fmt"}
+}
+
+type importAction struct{ singleResourceAction }
+
+func (importAction) Name() string { return "import" }
+func (importAction) Inputs() []actionInput {
+	return []actionInput{
+		{Key: "address", Label: "Resource address", Required: true, Placeholder: "aws_instance.web"},
+		{Key: "id", Label: "Import ID", Required: true},
+	}
+}
+func (importAction) BuildArgs(_ []string, inputs map[string]string) []string {
+	return []string{"import", inputs["address"], inputs["id"]}
+}
+
+type taintAction struct{ singleResourceAction }
+
+func (taintAction) Name() string          { return "taint" }
+func (taintAction) Inputs() []actionInput { return nil }
+func (taintAction) BuildArgs(selected []string, _ map[string]string) []string {
+	return []string{"taint", selected[0]}
+}
+
+type untaintAction struct{ singleResourceAction }
+
+func (untaintAction) Name() string          { return "untaint" }
+func (untaintAction) Inputs() []actionInput { return nil }
+func (untaintAction) BuildArgs(selected []string, _ map[string]string) []string {
+	return []string{"untaint", selected[0]}
+}
+
+type stateRmAction struct{ singleResourceAction }
+
+func (stateRmAction) Name() string          { return "state rm" }
+func (stateRmAction) Inputs() []actionInput { return nil }
+func (stateRmAction) BuildArgs(selected []string, _ map[string]string) []string {
+	return []string{"state", "rm", selected[0]}
+}
+
+type stateMvAction struct{ singleResourceAction }
+
+func (stateMvAction) Name() string { return "state mv" }
+func (stateMvAction) Inputs() []actionInput {
+	return []actionInput{
+		{Key: "destination", Label: "Destination address", Required: true},
+	}
+}
+func (stateMvAction) BuildArgs(selected []string, inputs map[string]string) []string {
+	return []string{"state", "mv", selected[0], inputs["destination"]}
+}
+
+type stateListAction struct{ broadAction }
+
+func (stateListAction) Name() string { return "state list" }
+func (stateListAction) BuildArgs(selected []string, _ map[string]string) []string {
+	return append([]string{"state", "list"}, selected...)
+}
+
+// registeredActions is the full catalog tfz knows about; viewAction filters
+// it down to what's Applicable to the current selection.
+var registeredActions = []Action{
+	planAction{},
+	applyAction{},
+	destroyAction{},
+	refreshAction{},
+	validateAction{},
+	fmtAction{},
+	importAction{},
+	taintAction{},
+	untaintAction{},
+	stateRmAction{},
+	stateMvAction{},
+	stateListAction{},
+}
+
+func filterApplicable(actions []Action, selected []string) []Action {
+	out := make([]Action, 0, len(actions))
+	for _, a := range actions {
+		if a.Applicable(selected) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+// TestFuzzyScoreCaseAndBoundaryBonuses checks that a match landing right
+// after a boundary (start/`_`/`-`/`.`/`/`/camelCase) with the query's exact
+// case outscores the same subsequence matched with mismatched case. The
+// "E" of webEc2Node is itself a camelCase boundary, so both candidates get
+// the boundary bonus and only the case bonus separates them. A query with
+// no subsequence in the label shouldn't match at all.
+func TestFuzzyScoreCaseAndBoundaryBonuses(t *testing.T) {
+	boundary, ok := fuzzyScore("aws_instance.ec2_web", "ec2")
+	if !ok {
+		t.Fatal("expected aws_instance.ec2_web to match \"ec2\"")
+	}
+	mixedCase, ok := fuzzyScore("aws_instance.webEc2Node", "ec2")
+	if !ok {
+		t.Fatal("expected aws_instance.webEc2Node to match \"ec2\"")
+	}
+	if boundary.score <= mixedCase.score {
+		t.Errorf("boundary-aligned exact-case match should outscore a mismatched-case one: %d vs %d", boundary.score, mixedCase.score)
+	}
+
+	if _, ok := fuzzyScore("aws_ecs_service", "ec2"); ok {
+		t.Error(`"ec2" has no subsequence in "aws_ecs_service" and should not match`)
+	}
+}
+
+// TestRebuildFilterOrdersByScore exercises fuzzyScore through rebuildFilter,
+// the way the target list actually uses it: matches are kept sorted by
+// descending score and non-matches drop out of m.filtered entirely.
+func TestRebuildFilterOrdersByScore(t *testing.T) {
+	m := model{
+		targets: []targetItem{
+			{Label: "aws_instance.ec2_web"},
+			{Label: "aws_instance.webEc2Node"},
+			{Label: "aws_ecs_service"},
+		},
+		filter: "ec2",
+	}
+	m.rebuildFilter()
+
+	if len(m.filtered) != 2 {
+		t.Fatalf("expected 2 matches, got %d: %v", len(m.filtered), m.filtered)
+	}
+	if got := m.targets[m.filtered[0]].Label; got != "aws_instance.ec2_web" {
+		t.Errorf("expected aws_instance.ec2_web to rank first, got %q", got)
+	}
+	for _, idx := range m.filtered {
+		if m.targets[idx].Label == "aws_ecs_service" {
+			t.Error("aws_ecs_service has no \"ec2\" subsequence and should have been filtered out")
+		}
+	}
+}
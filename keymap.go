@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyMap holds every key binding tfz's target/action steps respond to, so a
+// user can remap them (vim users rebinding GotoBottom to "G", or a
+// screencast/CI driving tfz deterministically through keys that can't
+// collide with anything else on their terminal) without touching the
+// switch statements themselves. Bindings are single keystrokes only —
+// bubbles/key.Binding matches one tea.KeyMsg at a time, so a multi-key
+// chord like vim's "gg" can't be expressed as a WithKeys entry.
+type KeyMap struct {
+	Up              key.Binding
+	Down            key.Binding
+	PageUp          key.Binding
+	PageDown        key.Binding
+	GotoTop         key.Binding
+	GotoBottom      key.Binding
+	ToggleSelect    key.Binding
+	SelectAll       key.Binding
+	SelectNone      key.Binding
+	InvertSelection key.Binding
+	ClearFilter     key.Binding
+	TogglePreview   key.Binding
+	PreviewUp       key.Binding
+	PreviewDown     key.Binding
+	SplitNarrower   key.Binding
+	SplitWider      key.Binding
+	Confirm         key.Binding
+	Help            key.Binding
+	Quit            key.Binding
+}
+
+// defaultKeyMap is what tfz binds to before any config.toml overlay is
+// applied, and what every binding falls back to if the overlay doesn't
+// mention it.
+func defaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:              key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:            key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		PageUp:          key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "half page up")),
+		PageDown:        key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdown", "half page down")),
+		GotoTop:         key.NewBinding(key.WithKeys("home"), key.WithHelp("home", "jump to first")),
+		GotoBottom:      key.NewBinding(key.WithKeys("end"), key.WithHelp("end", "jump to last")),
+		ToggleSelect:    key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle select")),
+		SelectAll:       key.NewBinding(key.WithKeys("ctrl+a"), key.WithHelp("ctrl+a", "select all")),
+		SelectNone:      key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "select none")),
+		InvertSelection: key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "invert selection")),
+		ClearFilter:     key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear filter")),
+		TogglePreview:   key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "toggle preview")),
+		PreviewUp:       key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "scroll preview up")),
+		PreviewDown:     key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "scroll preview down")),
+		SplitNarrower:   key.NewBinding(key.WithKeys("["), key.WithHelp("[", "shrink preview")),
+		SplitWider:      key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "grow preview")),
+		Confirm:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Help:            key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "toggle help")),
+		Quit:            key.NewBinding(key.WithKeys("ctrl+c", "q"), key.WithHelp("q", "quit")),
+	}
+}
+
+// ShortHelp and FullHelp satisfy bubbles/help's help.KeyMap interface, so
+// the `?` overlay renders whatever bindings are actually in effect rather
+// than a hard-coded cheat sheet.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.ToggleSelect, k.Confirm, k.Help, k.Quit}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.GotoTop, k.GotoBottom},
+		{k.ToggleSelect, k.SelectAll, k.SelectNone, k.InvertSelection},
+		{k.ClearFilter, k.TogglePreview, k.PreviewUp, k.PreviewDown, k.SplitNarrower, k.SplitWider},
+		{k.Confirm, k.Help, k.Quit},
+	}
+}
+
+// named returns every binding keyed by the lowercase name a config.toml
+// overlay uses to refer to it, e.g. `confirm = ["enter", "tab"]`.
+func (k *KeyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":              &k.Up,
+		"down":            &k.Down,
+		"pageup":          &k.PageUp,
+		"pagedown":        &k.PageDown,
+		"gototop":         &k.GotoTop,
+		"gotobottom":      &k.GotoBottom,
+		"toggleselect":    &k.ToggleSelect,
+		"selectall":       &k.SelectAll,
+		"selectnone":      &k.SelectNone,
+		"invertselection": &k.InvertSelection,
+		"clearfilter":     &k.ClearFilter,
+		"togglepreview":   &k.TogglePreview,
+		"previewup":       &k.PreviewUp,
+		"previewdown":     &k.PreviewDown,
+		"splitnarrower":   &k.SplitNarrower,
+		"splitwider":      &k.SplitWider,
+		"confirm":         &k.Confirm,
+		"help":            &k.Help,
+		"quit":            &k.Quit,
+	}
+}
+
+// applyOverlay replaces the trigger keys of every binding named in overlay
+// (and the key shown in its help entry, keeping the description), leaving
+// anything unmentioned at its default.
+func (k *KeyMap) applyOverlay(overlay map[string][]string) {
+	bindings := k.named()
+	for name, keys := range overlay {
+		if b, ok := bindings[name]; ok && len(keys) > 0 {
+			b.SetKeys(keys...)
+			b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+		}
+	}
+}
+
+// loadKeyMap builds the default KeyMap and, if a config.toml exists at
+// $XDG_CONFIG_HOME/tfz/config.toml (or ~/.config/tfz/config.toml), overlays
+// it. A missing or unreadable file just means "use the defaults" — it's
+// not an error users need to see.
+func loadKeyMap() KeyMap {
+	km := defaultKeyMap()
+
+	path, ok := keymapConfigPath()
+	if !ok {
+		return km
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return km
+	}
+
+	var overlay map[string][]string
+	if err := toml.Unmarshal(data, &overlay); err != nil {
+		return km
+	}
+	km.applyOverlay(overlay)
+	return km
+}
+
+func keymapConfigPath() (string, bool) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "tfz", "config.toml"), true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(home, ".config", "tfz", "config.toml"), true
+}
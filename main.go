@@ -3,13 +3,17 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"unicode"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/term"
@@ -20,26 +24,50 @@ type step int
 const (
 	stepTargets step = iota
 	stepAction
+	stepInputs
+	stepPlanConfirm
 )
 
+// targetSource locates the .tf block a target was parsed from, so the
+// preview pane can load it back on demand.
+type targetSource struct {
+	Path      string
+	StartLine int
+	EndLine   int
+}
+
 type targetItem struct {
 	Label    string
 	Selected bool
+	Source   targetSource
 }
 
 type model struct {
-	step         step
-	cursor       int
-	actionCursor int
-	targets      []targetItem
-	action       string
-	note         string
-	width        int
-	height       int
-	filter       string
-	filtered     []int
-	targetOffset int
-	actionOffset int
+	step          step
+	cursor        int
+	actionCursor  int
+	targets       []targetItem
+	action        string
+	note          string
+	width         int
+	height        int
+	filter        string
+	filtered      []int
+	matched       map[int][]int
+	targetOffset  int
+	actionOffset  int
+	showPreview   bool
+	previewOffset int
+	splitRatio    float64
+	planTree      bool
+	keys          KeyMap
+	showHelp      bool
+
+	pendingAction Action
+	pendingInputs []actionInput
+	inputIndex    int
+	inputValues   map[string]string
+	inputField    textinput.Model
 }
 
 var (
@@ -54,6 +82,7 @@ var (
 	headerTitle  = headerBar.Copy().Bold(true)
 	headerMeta   = headerBar.Copy().Foreground(lipgloss.Color("#BD93F9")).Bold(true)
 	activeStyle  = lipgloss.NewStyle().Background(lipgloss.Color("#3B3F52"))
+	matchStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B")).Underline(true)
 )
 
 var (
@@ -69,16 +98,28 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		if key.Matches(msg, m.keys.Quit) {
 			return m, tea.Quit
 		}
 
+		if m.showHelp {
+			m.showHelp = false
+			return m, nil
+		}
+		if key.Matches(msg, m.keys.Help) && (m.step == stepTargets || m.step == stepAction) {
+			m.showHelp = true
+			return m, nil
+		}
+
 		switch m.step {
 		case stepTargets:
 			return m.updateTargets(msg)
 		case stepAction:
 			return m.updateAction(msg)
+		case stepInputs:
+			return m.updateInputs(msg)
+		case stepPlanConfirm:
+			return m.updatePlanConfirm(msg)
 		}
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -89,22 +130,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case stepAction:
 			m.ensureActionVisible()
 		}
+	default:
+		if m.step == stepInputs {
+			return m.updateInputs(msg)
+		}
 	}
 	return m, nil
 }
 
 func (m model) updateTargets(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "up", "k":
+	switch {
+	case key.Matches(msg, m.keys.Up):
 		m.moveTargetCursor(-1)
-	case "down", "j":
+	case key.Matches(msg, m.keys.Down):
 		m.moveTargetCursor(1)
-	case " ":
+	case key.Matches(msg, m.keys.PageUp):
+		m.moveTargetCursor(-m.halfPage())
+	case key.Matches(msg, m.keys.PageDown):
+		m.moveTargetCursor(m.halfPage())
+	case key.Matches(msg, m.keys.GotoTop):
+		m.moveTargetCursor(-len(m.targets))
+	case key.Matches(msg, m.keys.GotoBottom):
+		m.moveTargetCursor(len(m.targets))
+	case key.Matches(msg, m.keys.ToggleSelect):
 		m.toggleSelection(m.cursor)
 		if m.hasSelection() && m.note != "" {
 			m.note = ""
 		}
-	case "enter":
+	case key.Matches(msg, m.keys.SelectAll):
+		m.selectAllOnly()
+		m.note = ""
+	case key.Matches(msg, m.keys.SelectNone):
+		m.selectNone()
+	case key.Matches(msg, m.keys.InvertSelection):
+		m.invertSelection()
+	case key.Matches(msg, m.keys.Confirm):
 		if !m.hasSelection() {
 			m.note = "Select at least one target (or 'all') with Space."
 			m.ensureTargetVisible()
@@ -115,11 +175,34 @@ func (m model) updateTargets(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.cursor = 0
 		m.actionCursor = 0
 		m.actionOffset = 0
-	case "backspace":
+	case key.Matches(msg, m.keys.ClearFilter):
+		m.filter = ""
+		m.rebuildFilter()
+	case msg.String() == "backspace":
 		if len(m.filter) > 0 {
 			m.filter = m.filter[:len(m.filter)-1]
 			m.rebuildFilter()
 		}
+	case key.Matches(msg, m.keys.TogglePreview):
+		m.showPreview = !m.showPreview
+		m.previewOffset = 0
+	case m.showPreview && key.Matches(msg, m.keys.PreviewUp):
+		m.previewOffset -= previewScrollStep
+		if m.previewOffset < 0 {
+			m.previewOffset = 0
+		}
+	case m.showPreview && key.Matches(msg, m.keys.PreviewDown):
+		m.previewOffset += previewScrollStep
+	case m.showPreview && key.Matches(msg, m.keys.SplitNarrower):
+		m.splitRatio -= splitRatioStep
+		if m.splitRatio < minSplitRatio {
+			m.splitRatio = minSplitRatio
+		}
+	case m.showPreview && key.Matches(msg, m.keys.SplitWider):
+		m.splitRatio += splitRatioStep
+		if m.splitRatio > maxSplitRatio {
+			m.splitRatio = maxSplitRatio
+		}
 	default:
 		if msg.Type == tea.KeyRunes && len(msg.Runes) > 0 {
 			m.filter += string(msg.Runes)
@@ -130,38 +213,187 @@ func (m model) updateTargets(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// halfPage is how many rows PageUp/PageDown jump the cursor by: half the
+// currently visible target list height, with a floor so it's still useful
+// on a very short terminal.
+func (m model) halfPage() int {
+	visible, _ := m.targetVisibleRows(m.innerWidth(), m.currentHeight())
+	h := visible / 2
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
 func (m model) updateAction(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "up", "k":
+	available := m.availableActions()
+	switch {
+	case key.Matches(msg, m.keys.Up):
 		if m.actionCursor > 0 {
 			m.actionCursor--
 		}
-	case "down", "j":
-		if m.actionCursor < len(actions)-1 {
+	case key.Matches(msg, m.keys.Down):
+		if m.actionCursor < len(available)-1 {
 			m.actionCursor++
 		}
-	case "enter":
-		m.action = actions[m.actionCursor]
-		return m, tea.Quit
+	case key.Matches(msg, m.keys.Confirm):
+		if m.actionCursor >= len(available) {
+			return m, nil
+		}
+		chosen := available[m.actionCursor]
+		m.action = chosen.Name()
+		m.pendingAction = chosen
+		m.pendingInputs = chosen.Inputs()
+		m.inputValues = make(map[string]string, len(m.pendingInputs))
+		if len(m.pendingInputs) == 0 {
+			return m.finishActionSelection()
+		}
+		m.inputIndex = 0
+		m.step = stepInputs
+		m.inputField = newActionTextInput(m.pendingInputs[0])
+		return m, textinput.Blink
 	}
 	m.ensureActionVisible()
 	return m, nil
 }
 
+// availableActions is the action catalog filtered down to what applies to
+// the current target selection (e.g. `state rm` only shows up when exactly
+// one specific target is selected).
+func (m model) availableActions() []Action {
+	return filterApplicable(registeredActions, m.selectedTargets())
+}
+
+// finishActionSelection is reached once every actionInput an Action needs
+// has been collected (or it needed none): plan detours through the
+// plan-tree prompt, everything else is ready for main() to exec.
+func (m model) finishActionSelection() (tea.Model, tea.Cmd) {
+	if m.action == "plan" {
+		m.step = stepPlanConfirm
+		return m, nil
+	}
+	return m, tea.Quit
+}
+
+func newActionTextInput(input actionInput) textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = input.Placeholder
+	ti.CharLimit = 256
+	ti.Focus()
+	return ti
+}
+
+// updateInputs drives the per-action textinput sub-step, advancing through
+// m.pendingInputs one at a time and validating each (including destroy's
+// typed confirmation) before handing off to finishActionSelection.
+func (m model) updateInputs(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.step = stepAction
+			return m, nil
+		case "enter":
+			cur := m.pendingInputs[m.inputIndex]
+			val := m.inputField.Value()
+			if cur.Confirm != "" && val != cur.Confirm {
+				m.note = fmt.Sprintf("type %q to confirm", cur.Confirm)
+				return m, nil
+			}
+			if cur.Required && val == "" {
+				m.note = cur.Label + " is required"
+				return m, nil
+			}
+			m.note = ""
+			m.inputValues[cur.Key] = val
+			m.inputIndex++
+			if m.inputIndex >= len(m.pendingInputs) {
+				return m.finishActionSelection()
+			}
+			m.inputField = newActionTextInput(m.pendingInputs[m.inputIndex])
+			return m, textinput.Blink
+		}
+	}
+	var cmd tea.Cmd
+	m.inputField, cmd = m.inputField.Update(msg)
+	return m, cmd
+}
+
+// updatePlanConfirm handles the "open the plan in the JSON tree browser
+// afterwards?" prompt shown after picking the plan action.
+func (m model) updatePlanConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.planTree = true
+		return m, tea.Quit
+	case "n", "N", "enter":
+		m.planTree = false
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
 func (m model) View() string {
+	if m.showHelp {
+		return m.viewHelp()
+	}
 	switch m.step {
 	case stepTargets:
 		return m.viewTargets()
 	case stepAction:
 		return m.viewAction()
+	case stepInputs:
+		return m.viewInputs()
+	case stepPlanConfirm:
+		return m.viewPlanConfirm()
 	default:
 		return ""
 	}
 }
 
+func (m model) viewInputs() string {
+	var b strings.Builder
+	inner := m.innerWidth()
+	writeHeader(&b, inner, strings.ToUpper(m.pendingAction.Name())+" INPUT")
+	if m.inputIndex < len(m.pendingInputs) {
+		writeWrapped(&b, sectionStyle, m.pendingInputs[m.inputIndex].Label, inner)
+	}
+	b.WriteString("\n")
+	b.WriteString(m.inputField.View() + "\n")
+	if m.note != "" {
+		b.WriteString("\n")
+		writeWrapped(&b, noteStyle, m.note, inner)
+	}
+	return b.String()
+}
+
+func (m model) viewPlanConfirm() string {
+	var b strings.Builder
+	inner := m.innerWidth()
+	writeHeader(&b, inner, "PLAN")
+	b.WriteString("\n")
+	writeWrapped(&b, itemStyle, "Browse the plan in the interactive JSON tree viewer after it runs? [y/N]", inner)
+	return b.String()
+}
+
+// viewHelp renders the effective keymap (defaults overlaid by config.toml)
+// via bubbles/help, so a remapped binding shows its actual key rather than
+// whatever tfz shipped with.
+func (m model) viewHelp() string {
+	var b strings.Builder
+	inner := m.innerWidth()
+	writeHeader(&b, inner, "KEYS")
+	h := help.New()
+	h.Width = inner
+	b.WriteString(h.View(m.keys))
+	b.WriteString("\n")
+	writeWrapped(&b, noteStyle, "press any key to close", inner)
+	return b.String()
+}
+
 func (m model) viewTargets() string {
 	var b strings.Builder
 	inner := m.innerWidth()
+	listW := m.listWidth()
 	height := m.currentHeight()
 	writeHeader(&b, inner, "TARGET SELECTOR")
 	writeWrapped(&b, filterStyle, fmt.Sprintf("FILTER: %s", m.filter), inner)
@@ -190,14 +422,21 @@ func (m model) viewTargets() string {
 		}
 		prefixPlain := fmt.Sprintf("%s %s ", cursorPlain, checkPlain)
 		prefixLen := len([]rune(prefixPlain))
-		labelWidth := inner - prefixLen
+		labelWidth := listW - prefixLen
 		if labelWidth < 1 {
 			labelWidth = 1
 		}
-		labelLines := wrapLines(item.Label, labelWidth)
+		var labelLines []string
+		if positions, ok := m.matched[i]; ok {
+			labelLines = renderMatchedLabel(item.Label, positions, labelWidth)
+		} else {
+			for _, line := range wrapLines(item.Label, labelWidth) {
+				labelLines = append(labelLines, itemStyle.Render(line))
+			}
+		}
 		for li, line := range labelLines {
 			if li == 0 {
-				out := fmt.Sprintf("%s %s %s", cursorStyled, checkStyled, itemStyle.Render(line))
+				out := fmt.Sprintf("%s %s %s", cursorStyled, checkStyled, line)
 				if m.cursor == i {
 					out = activeStyle.Render(out)
 				}
@@ -205,7 +444,7 @@ func (m model) viewTargets() string {
 				continue
 			}
 			indent := strings.Repeat(" ", prefixLen)
-			out := indent + itemStyle.Render(line)
+			out := indent + line
 			if m.cursor == i {
 				out = activeStyle.Render(out)
 			}
@@ -215,7 +454,11 @@ func (m model) viewTargets() string {
 	if height > 0 {
 		visible, showNote := m.targetVisibleRows(inner, height)
 		start, end := clampSlice(m.targetOffset, visible, len(lines))
-		for _, line := range lines[start:end] {
+		body := lines[start:end]
+		if pw := m.previewWidth(); pw > 0 {
+			body = zipColumns(body, m.previewLines(pw, visible), listW, pw)
+		}
+		for _, line := range body {
 			b.WriteString(line + "\n")
 		}
 		if showNote {
@@ -224,6 +467,9 @@ func (m model) viewTargets() string {
 		}
 		return padToHeight(b.String(), height)
 	}
+	if pw := m.previewWidth(); pw > 0 {
+		lines = zipColumns(lines, m.previewLines(pw, len(lines)), listW, pw)
+	}
 	for _, line := range lines {
 		b.WriteString(line + "\n")
 	}
@@ -241,7 +487,7 @@ func (m model) viewAction() string {
 	writeHeader(&b, inner, "ACTION SELECTOR")
 	b.WriteString("\n")
 	var lines []string
-	for i, item := range actions {
+	for i, item := range m.actionLabels() {
 		cursorPlain := " "
 		cursorStyled := " "
 		if m.actionCursor == i {
@@ -295,6 +541,36 @@ func (m model) innerWidth() int {
 	return frameWidth
 }
 
+// listWidth is the width available to the target list itself: the full
+// frame width, minus the preview column (and its separator) when the
+// preview pane is toggled on.
+func (m model) listWidth() int {
+	inner := m.innerWidth()
+	if !m.showPreview || inner <= 0 {
+		return inner
+	}
+	pw := int(float64(inner) * m.splitRatio)
+	if pw < minPreviewWidth {
+		return inner
+	}
+	lw := inner - pw - len(previewSeparator)
+	if lw < minPreviewWidth {
+		return inner
+	}
+	return lw
+}
+
+// previewWidth is the complementary column width for the HCL preview pane,
+// or 0 when there isn't room to show one.
+func (m model) previewWidth() int {
+	inner := m.innerWidth()
+	lw := m.listWidth()
+	if lw >= inner {
+		return 0
+	}
+	return inner - lw - len(previewSeparator)
+}
+
 func (m model) currentWidth() int {
 	if m.width > 0 {
 		return m.width
@@ -319,14 +595,29 @@ func (m model) currentHeight() int {
 
 func (m *model) rebuildFilter() {
 	m.filtered = m.filtered[:0]
+	m.matched = nil
 	if m.filter == "" {
 		return
 	}
-	query := strings.ToLower(m.filter)
+	type scoredMatch struct {
+		index  int
+		result matchResult
+	}
+	var matches []scoredMatch
 	for i, item := range m.targets {
-		if fuzzyMatch(strings.ToLower(item.Label), query) {
-			m.filtered = append(m.filtered, i)
+		result, ok := fuzzyScore(item.Label, m.filter)
+		if !ok {
+			continue
 		}
+		matches = append(matches, scoredMatch{index: i, result: result})
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		return matches[a].result.score > matches[b].result.score
+	})
+	m.matched = make(map[int][]int, len(matches))
+	for _, match := range matches {
+		m.filtered = append(m.filtered, match.index)
+		m.matched[match.index] = match.result.positions
 	}
 	if len(m.filtered) == 0 {
 		m.cursor = 0
@@ -444,12 +735,13 @@ func (m model) targetVisibleRows(inner int, height int) (int, bool) {
 
 func (m *model) ensureTargetVisible() {
 	inner := m.innerWidth()
+	listW := m.listWidth()
 	height := m.currentHeight()
 	if height <= 0 {
 		return
 	}
 	indexes := m.targetIndexes()
-	total := m.targetTotalLines(inner, indexes)
+	total := m.targetTotalLines(listW, indexes)
 	visible, _ := m.targetVisibleRows(inner, height)
 	if total <= 0 || visible <= 0 {
 		m.targetOffset = 0
@@ -462,7 +754,7 @@ func (m *model) ensureTargetVisible() {
 	if m.targetOffset > maxOffset {
 		m.targetOffset = maxOffset
 	}
-	cursorLine, _ := m.targetCursorLine(inner, indexes)
+	cursorLine, _ := m.targetCursorLine(listW, indexes)
 	if cursorLine < m.targetOffset {
 		m.targetOffset = cursorLine
 		return
@@ -485,10 +777,21 @@ func (m model) actionLabelWidth(inner int) int {
 	return labelWidth
 }
 
+// actionLabels renders the currently applicable Actions down to the plain
+// label strings the wrapping/layout helpers below operate on.
+func (m model) actionLabels() []string {
+	available := m.availableActions()
+	labels := make([]string, len(available))
+	for i, a := range available {
+		labels[i] = a.Name()
+	}
+	return labels
+}
+
 func (m model) actionCursorLine(inner int) int {
 	line := 0
 	labelWidth := m.actionLabelWidth(inner)
-	for i, item := range actions {
+	for i, item := range m.actionLabels() {
 		lines := wrapLines(item, labelWidth)
 		if i == m.actionCursor {
 			if len(lines) == 0 {
@@ -504,7 +807,7 @@ func (m model) actionCursorLine(inner int) int {
 func (m model) actionTotalLines(inner int) int {
 	total := 0
 	labelWidth := m.actionLabelWidth(inner)
-	for _, item := range actions {
+	for _, item := range m.actionLabels() {
 		total += len(wrapLines(item, labelWidth))
 	}
 	return total
@@ -554,26 +857,339 @@ func (m *model) ensureActionVisible() {
 	}
 }
 
-func fuzzyMatch(text, query string) bool {
+// matchResult is the outcome of scoring a label against a fuzzy query: an
+// fzf-style score plus the exact rune positions in the label that matched,
+// in query order.
+type matchResult struct {
+	score     int
+	positions []int
+}
+
+// fuzzyNegInf stands in for "unreachable" cells in the scoring grid. It
+// stays far enough from zero that adding a handful of bonuses/penalties to
+// it can never cross back over a real score.
+const fuzzyNegInf = math.MinInt32 / 2
+
+// fuzzyScore ranks label against query the way fzf's algorithm does: a
+// len(query) x len(label) dynamic-programming grid where each cell holds the
+// best score for aligning query[:i+1] ending at label[:j+1], with bonuses
+// for word-boundary and consecutive matches and penalties for gaps. The
+// match positions are recovered by backtracking from the best-scoring cell
+// in the last row.
+func fuzzyScore(label, query string) (matchResult, bool) {
 	if query == "" {
-		return true
-	}
-	ti := 0
-	for _, r := range query {
-		found := false
-		for ti < len(text) {
-			if rune(text[ti]) == r {
-				found = true
-				ti++
-				break
+		return matchResult{}, true
+	}
+	runes := []rune(label)
+	qrunes := []rune(query)
+	n, m := len(qrunes), len(runes)
+	if m < n {
+		return matchResult{}, false
+	}
+
+	const (
+		scoreMatch       = 16
+		bonusBoundary    = 8
+		bonusConsecutive = 4
+		bonusCaseMatch   = 1
+		penaltyGapStart  = -3
+		penaltyGapExtend = -1
+	)
+
+	lowerLabel := make([]rune, m)
+	for i, r := range runes {
+		lowerLabel[i] = unicode.ToLower(r)
+	}
+	lowerQuery := make([]rune, n)
+	for i, r := range qrunes {
+		lowerQuery[i] = unicode.ToLower(r)
+	}
+
+	// isBoundary reports whether label[j] starts a new "word": the start of
+	// the string, right after a path/identifier separator, or a camelCase
+	// transition.
+	isBoundary := func(j int) bool {
+		if j == 0 {
+			return true
+		}
+		switch runes[j-1] {
+		case '_', '-', '.', '/':
+			return true
+		}
+		return unicode.IsUpper(runes[j]) && unicode.IsLower(runes[j-1])
+	}
+
+	// d[i][j] is the best score of a match that matches query[i] exactly at
+	// label[j]. carry[i][j] is the best score achievable using label[:j+1],
+	// decaying by penaltyGapExtend per unmatched column so that longer gaps
+	// cost more (the Smith-Waterman-style affine gap penalty the spec asks
+	// for). matchedHere records whether carry[i][j] is realized by a match
+	// at j, which is what backtracking needs.
+	d := make([][]int, n)
+	carry := make([][]int, n)
+	matchedHere := make([][]bool, n)
+	for i := 0; i < n; i++ {
+		d[i] = make([]int, m)
+		carry[i] = make([]int, m)
+		matchedHere[i] = make([]bool, m)
+		for j := 0; j < m; j++ {
+			d[i][j] = fuzzyNegInf
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			if lowerQuery[i] == lowerLabel[j] {
+				bonus := 0
+				if isBoundary(j) {
+					bonus += bonusBoundary
+				}
+				if runes[j] == qrunes[i] {
+					bonus += bonusCaseMatch
+				}
+				best := fuzzyNegInf
+				if i == 0 {
+					best = scoreMatch + bonus
+				} else {
+					if j > 0 && d[i-1][j-1] > fuzzyNegInf {
+						if v := d[i-1][j-1] + scoreMatch + bonus + bonusConsecutive; v > best {
+							best = v
+						}
+					}
+					if j > 0 && carry[i-1][j-1] > fuzzyNegInf {
+						if v := carry[i-1][j-1] + scoreMatch + bonus + penaltyGapStart; v > best {
+							best = v
+						}
+					}
+				}
+				d[i][j] = best
+			}
+			if j == 0 {
+				carry[i][j] = d[i][j]
+				matchedHere[i][j] = d[i][j] > fuzzyNegInf
+				continue
+			}
+			extended := fuzzyNegInf
+			if carry[i][j-1] > fuzzyNegInf {
+				extended = carry[i][j-1] + penaltyGapExtend
+			}
+			if d[i][j] >= extended {
+				carry[i][j] = d[i][j]
+				matchedHere[i][j] = d[i][j] > fuzzyNegInf
+			} else {
+				carry[i][j] = extended
+			}
+		}
+	}
+
+	best := fuzzyNegInf
+	endCol := -1
+	for j := 0; j < m; j++ {
+		if carry[n-1][j] > best {
+			best = carry[n-1][j]
+			endCol = j
+		}
+	}
+	if endCol == -1 {
+		return matchResult{}, false
+	}
+
+	positions := make([]int, n)
+	col := endCol
+	for i := n - 1; i >= 0; i-- {
+		for col >= 0 && !matchedHere[i][col] {
+			col--
+		}
+		if col < 0 {
+			return matchResult{}, false
+		}
+		positions[i] = col
+		col--
+	}
+
+	return matchResult{score: best, positions: positions}, true
+}
+
+// renderMatchedLabel wraps label to width the same way wrapLines does, but
+// renders matched rune positions with matchStyle and everything else with
+// itemStyle.
+func renderMatchedLabel(label string, positions []int, width int) []string {
+	runes := []rune(label)
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+	if width <= 0 {
+		width = len(runes)
+		if width == 0 {
+			width = 1
+		}
+	}
+	var out []string
+	for start := 0; start < len(runes) || start == 0; start += width {
+		end := start + width
+		if end > len(runes) {
+			end = len(runes)
+		}
+		var b strings.Builder
+		for i := start; i < end; i++ {
+			ch := string(runes[i])
+			if matched[i] {
+				b.WriteString(matchStyle.Render(ch))
+			} else {
+				b.WriteString(itemStyle.Render(ch))
 			}
-			ti++
 		}
-		if !found {
-			return false
+		out = append(out, b.String())
+		if end == len(runes) {
+			break
+		}
+	}
+	return out
+}
+
+// currentItem returns the target under the cursor, or nil if the cursor is
+// out of range (e.g. the target list is empty).
+func (m model) currentItem() *targetItem {
+	if m.cursor < 0 || m.cursor >= len(m.targets) {
+		return nil
+	}
+	return &m.targets[m.cursor]
+}
+
+// previewLines renders up to `rows` lines of the .tf source backing the
+// highlighted target, starting from m.previewOffset, wrapped to width and
+// syntax-colored.
+func (m model) previewLines(width int, rows int) []string {
+	if rows <= 0 {
+		return nil
+	}
+	item := m.currentItem()
+	if item == nil || item.Source.Path == "" {
+		return []string{noteStyle.Render("(no preview available)")}
+	}
+	raw, err := readSourceRange(item.Source)
+	if err != nil {
+		return []string{noteStyle.Render(fmt.Sprintf("(preview error: %v)", err))}
+	}
+	var rendered []string
+	for _, line := range raw {
+		for _, wrapped := range wrapLines(line, width) {
+			rendered = append(rendered, highlightHCLLine(wrapped))
+		}
+	}
+	offset := m.previewOffset
+	if offset > len(rendered) {
+		offset = len(rendered)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	end := offset + rows
+	if end > len(rendered) {
+		end = len(rendered)
+	}
+	return rendered[offset:end]
+}
+
+// readSourceRange loads the lines [StartLine, EndLine] (1-indexed,
+// inclusive) of src.Path.
+func readSourceRange(src targetSource) ([]string, error) {
+	file, err := os.Open(src.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var out []string
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line < src.StartLine {
+			continue
+		}
+		if line > src.EndLine {
+			break
+		}
+		out = append(out, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var (
+	hclKeywordStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF79C6")).Bold(true)
+	hclStringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C"))
+	hclCommentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#6272A4")).Italic(true)
+	hclKeywords     = map[string]bool{"resource": true, "module": true, "variable": true}
+	reHCLString     = regexp.MustCompile(`"[^"]*"`)
+	reHCLWord       = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_-]*`)
+)
+
+// highlightHCLLine applies a minimal HCL syntax coloring to one source line:
+// whole-line comments, quoted strings, and the block keywords.
+func highlightHCLLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+		return hclCommentStyle.Render(line)
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range reHCLString.FindAllStringIndex(line, -1) {
+		b.WriteString(highlightHCLWords(line[last:loc[0]]))
+		b.WriteString(hclStringStyle.Render(line[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(highlightHCLWords(line[last:]))
+	return b.String()
+}
+
+func highlightHCLWords(segment string) string {
+	return reHCLWord.ReplaceAllStringFunc(segment, func(word string) string {
+		if hclKeywords[word] {
+			return hclKeywordStyle.Render(word)
+		}
+		return word
+	})
+}
+
+// zipColumns lays left and right out side by side, padding the shorter
+// column to its target display width (ignoring ANSI styling codes) and
+// joining with previewSeparator.
+func zipColumns(left, right []string, leftWidth, rightWidth int) []string {
+	n := len(left)
+	if len(right) > n {
+		n = len(right)
+	}
+	out := make([]string, n)
+	for i := 0; i < n; i++ {
+		l := ""
+		if i < len(left) {
+			l = left[i]
 		}
+		r := ""
+		if i < len(right) {
+			r = right[i]
+		}
+		out[i] = padVisible(l, leftWidth) + previewSeparator + r
 	}
-	return true
+	return out
+}
+
+// padVisible pads s with spaces until its visible (ANSI-stripped) width
+// reaches width.
+func padVisible(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	w := lipgloss.Width(s)
+	if w >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-w)
 }
 
 func writeWrapped(b *strings.Builder, style lipgloss.Style, text string, width int) {
@@ -684,6 +1300,27 @@ func (m *model) selectAllOnly() {
 	}
 }
 
+func (m *model) selectNone() {
+	for i := range m.targets {
+		m.targets[i].Selected = false
+	}
+}
+
+// invertSelection flips each individual target's membership in the
+// effective selection, expanding the "all" sentinel into concrete
+// per-target selections first so the inversion has something to flip.
+func (m *model) invertSelection() {
+	if len(m.targets) == 0 {
+		return
+	}
+	allSelected := m.targets[0].Selected
+	m.targets[0].Selected = false
+	for i := 1; i < len(m.targets); i++ {
+		effective := allSelected || m.targets[i].Selected
+		m.targets[i].Selected = !effective
+	}
+}
+
 func (m model) hasSelection() bool {
 	for _, item := range m.targets {
 		if item.Selected {
@@ -706,43 +1343,55 @@ func (m model) selectedTargets() []string {
 	return out
 }
 
-var (
-	reModule  = regexp.MustCompile(`^\s*module\s+"([^"]+)"`)
-	reRes     = regexp.MustCompile(`^\s*resource\s+"([^"]+)"\s+"([^"]+)"`)
-	actions   = []string{"plan", "apply"}
-	tfExt     = ".tf"
-	allTarget = "all"
+const allTarget = "all"
+
+const (
+	previewSeparator  = " │ "
+	minPreviewWidth   = 10
+	previewScrollStep = 5
+	splitRatioStep    = 0.05
+	minSplitRatio     = 0.2
+	maxSplitRatio     = 0.8
+	defaultSplitRatio = 0.5
 )
 
 func main() {
-	if len(os.Args) > 1 {
-		switch os.Args[1] {
-		case "-h", "--help", "help":
+	dir := "."
+	for _, arg := range os.Args[1:] {
+		switch {
+		case arg == "-h" || arg == "--help" || arg == "help":
 			fmt.Print(helpString())
 			return
-		case "-v", "--version", "version":
+		case arg == "-v" || arg == "--version" || arg == "version":
 			fmt.Println(versionString())
 			return
+		case strings.HasPrefix(arg, "-dir="):
+			dir = strings.TrimPrefix(arg, "-dir=")
 		}
 	}
 
-	targets, err := findTargets(".")
+	targets, sources, warnings, err := findTargets(dir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
 
 	items := make([]targetItem, 0, len(targets)+1)
 	items = append(items, targetItem{Label: allTarget})
 	for _, t := range targets {
-		items = append(items, targetItem{Label: t})
+		items = append(items, targetItem{Label: t, Source: sources[t]})
 	}
 
 	note := ""
 	m := model{
-		step:    stepTargets,
-		targets: items,
-		note:    note,
+		step:       stepTargets,
+		targets:    items,
+		note:       note,
+		splitRatio: defaultSplitRatio,
+		keys:       loadKeyMap(),
 	}
 	prog := tea.NewProgram(m)
 	final, err := prog.Run()
@@ -755,16 +1404,21 @@ func main() {
 	if !ok {
 		os.Exit(1)
 	}
-	if fm.action == "" {
+	if fm.action == "" || fm.pendingAction == nil {
 		return
 	}
 
-	args := []string{fm.action}
 	selected := fm.selectedTargets()
-	for _, t := range selected {
-		args = append(args, "-target="+t)
+
+	if fm.action == "plan" && fm.planTree {
+		if err := runPlanTree(targetFlags(selected)); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
+	args := fm.pendingAction.BuildArgs(selected, fm.inputValues)
 	fmt.Printf("terraform %s\n", strings.Join(args, " "))
 	cmd := exec.Command("terraform", args...)
 	cmd.Stdout = os.Stdout
@@ -800,53 +1454,6 @@ Usage:
 Options:
   -h, --help     Show this help
   -v, --version  Print version
+  -dir=<path>    Directory to scan for .tf files (default: .)
 `
 }
-
-func findTargets(dir string) ([]string, error) {
-	matches, err := filepath.Glob(filepath.Join(dir, "*"+tfExt))
-	if err != nil {
-		return nil, err
-	}
-	if len(matches) == 0 {
-		return nil, nil
-	}
-
-	seen := make(map[string]struct{})
-	for _, path := range matches {
-		if err := collectTargets(path, seen); err != nil {
-			return nil, err
-		}
-	}
-
-	var out []string
-	for target := range seen {
-		out = append(out, target)
-	}
-	sort.Strings(out)
-	return out, nil
-}
-
-func collectTargets(path string, seen map[string]struct{}) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") {
-			continue
-		}
-		if match := reModule.FindStringSubmatch(line); match != nil {
-			seen["module."+match[1]] = struct{}{}
-			continue
-		}
-		if match := reRes.FindStringSubmatch(line); match != nil {
-			seen["resource."+match[1]+"."+match[2]] = struct{}{}
-		}
-	}
-	return scanner.Err()
-}
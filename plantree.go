@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// planNode is one node of the collapsible tree built from `terraform show
+// -json`'s output: either an object/array with Children, or a scalar leaf
+// holding its rendered Value.
+type planNode struct {
+	Key       string
+	Kind      string // "object", "array", "scalar"
+	Value     string
+	Children  []*planNode
+	Expanded  bool
+	Path      string
+	IsReplace bool // true for an actions[*] scalar that's part of a delete+create replace pair
+}
+
+var (
+	planKeyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#8BE9FD"))
+	planValueStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#F8F8F2"))
+	planCreateStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B")).Bold(true)
+	planUpdateStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C")).Bold(true)
+	planDeleteStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Bold(true)
+	planReplaceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF79C6")).Bold(true)
+)
+
+// runPlanTree runs `terraform plan -out=<tmpfile>` with the given target
+// flags, feeds the resulting plan through `terraform show -json`, and drops
+// the user into the interactive tree browser. The plan file is a scratch
+// artifact and is always removed before returning.
+func runPlanTree(targetArgs []string) error {
+	planFile, err := os.CreateTemp("", "tfz-plan-*.tfplan")
+	if err != nil {
+		return fmt.Errorf("create plan file: %w", err)
+	}
+	planPath := planFile.Name()
+	planFile.Close()
+	defer os.Remove(planPath)
+
+	planArgs := append([]string{"plan", "-out=" + planPath}, targetArgs...)
+	fmt.Printf("terraform %s\n", strings.Join(planArgs, " "))
+	planCmd := exec.Command("terraform", planArgs...)
+	planCmd.Stdout = os.Stdout
+	planCmd.Stderr = os.Stderr
+	planCmd.Stdin = os.Stdin
+	if err := planCmd.Run(); err != nil {
+		return fmt.Errorf("terraform plan: %w", err)
+	}
+
+	showCmd := exec.Command("terraform", "show", "-json", planPath)
+	var out bytes.Buffer
+	showCmd.Stdout = &out
+	showCmd.Stderr = os.Stderr
+	if err := showCmd.Run(); err != nil {
+		return fmt.Errorf("terraform show -json: %w", err)
+	}
+
+	root, err := buildPlanTree(&out)
+	if err != nil {
+		return fmt.Errorf("parse plan json: %w", err)
+	}
+	root.Expanded = true
+
+	prog := tea.NewProgram(newPlanModel(root))
+	_, err = prog.Run()
+	return err
+}
+
+// buildPlanTree decodes r token by token rather than unmarshaling into
+// interface{}, so a large plan doesn't need two copies of the document in
+// memory at once.
+func buildPlanTree(r io.Reader) (*planNode, error) {
+	dec := json.NewDecoder(r)
+	return decodePlanValue(dec, "root", "$")
+}
+
+func decodePlanValue(dec *json.Decoder, key, path string) (*planNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, isDelim := tok.(json.Delim)
+	if !isDelim {
+		return &planNode{Key: key, Kind: "scalar", Value: scalarString(tok), Path: path}, nil
+	}
+
+	switch delim {
+	case '{':
+		node := &planNode{Key: key, Kind: "object", Path: path}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			childKey, _ := keyTok.(string)
+			child, err := decodePlanValue(dec, childKey, path+"."+childKey)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+		if _, err := dec.Token(); err != nil { // consume '}'
+			return nil, err
+		}
+		return node, nil
+	case '[':
+		node := &planNode{Key: key, Kind: "array", Path: path}
+		idx := 0
+		for dec.More() {
+			child, err := decodePlanValue(dec, strconv.Itoa(idx), fmt.Sprintf("%s[%d]", path, idx))
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+			idx++
+		}
+		if _, err := dec.Token(); err != nil { // consume ']'
+			return nil, err
+		}
+		if key == "actions" && isChangeActionsPath(path) && isReplaceActionPair(node.Children) {
+			for _, c := range node.Children {
+				c.IsReplace = true
+			}
+		}
+		return node, nil
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// isChangeActionsPath reports whether path is a
+// resource_changes[*].change.actions node — the only array styledPlanValue
+// and the replace-pair check are meant to apply to, not any other
+// unrelated array in the plan JSON that happens to be named "actions".
+func isChangeActionsPath(path string) bool {
+	return strings.HasSuffix(path, ".change.actions")
+}
+
+// isReplaceActionPair reports whether children is an actions array of
+// exactly ["delete","create"] or ["create","delete"] — `terraform show
+// -json`'s encoding of a replace/recreate, which decodes as two sibling
+// scalar nodes rather than one joined "delete, create" value.
+func isReplaceActionPair(children []*planNode) bool {
+	if len(children) != 2 {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, c := range children {
+		if c.Kind != "scalar" {
+			return false
+		}
+		seen[strings.Trim(c.Value, `"`)] = true
+	}
+	return len(seen) == 2 && seen["delete"] && seen["create"]
+}
+
+func scalarString(tok json.Token) string {
+	switch v := tok.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// planModel is the bubbletea model for the collapsible plan JSON browser.
+type planModel struct {
+	root      *planNode
+	visible   []*planNode
+	cursor    int
+	offset    int
+	width     int
+	height    int
+	searching bool
+	search    string
+	note      string
+}
+
+func newPlanModel(root *planNode) planModel {
+	m := planModel{root: root}
+	m.rebuildVisible()
+	return m
+}
+
+func (m *planModel) rebuildVisible() {
+	m.visible = m.visible[:0]
+	var walk func(n *planNode)
+	walk = func(n *planNode) {
+		m.visible = append(m.visible, n)
+		if n.Expanded {
+			for _, c := range n.Children {
+				walk(c)
+			}
+		}
+	}
+	walk(m.root)
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m planModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m planModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		return m.updateBrowse(msg)
+	}
+	return m, nil
+}
+
+func (m planModel) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.visible)-1 {
+			m.cursor++
+		}
+	case "enter", "l":
+		m.expandCurrent(true)
+	case "h":
+		m.expandCurrent(false)
+	case "E":
+		m.setExpandedUnder(m.currentNode(), true)
+		m.rebuildVisible()
+	case "C":
+		m.setExpandedUnder(m.currentNode(), false)
+		m.rebuildVisible()
+	case "/":
+		m.searching = true
+		m.search = ""
+	case "y":
+		node := m.currentNode()
+		if node != nil {
+			if err := copyToClipboard(node.Path); err != nil {
+				m.note = fmt.Sprintf("copy failed: %v", err)
+			} else {
+				m.note = "copied " + node.Path
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m planModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "esc":
+		m.searching = false
+	case "backspace":
+		if len(m.search) > 0 {
+			m.search = m.search[:len(m.search)-1]
+		}
+	default:
+		if msg.Type == tea.KeyRunes && len(msg.Runes) > 0 {
+			m.search += string(msg.Runes)
+		}
+	}
+	if idx := m.findMatch(m.search); idx >= 0 {
+		m.cursor = idx
+	}
+	return m, nil
+}
+
+// findMatch scores every visible row's key and value against query with
+// fuzzyScore and returns the index of the best match, or -1.
+func (m planModel) findMatch(query string) int {
+	if query == "" {
+		return -1
+	}
+	best := -1
+	bestScore := fuzzyNegInf
+	for i, n := range m.visible {
+		candidate := n.Key
+		if n.Kind == "scalar" {
+			candidate += " " + n.Value
+		}
+		result, ok := fuzzyScore(candidate, query)
+		if !ok || result.score <= bestScore {
+			continue
+		}
+		bestScore = result.score
+		best = i
+	}
+	return best
+}
+
+func (m planModel) currentNode() *planNode {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return nil
+	}
+	return m.visible[m.cursor]
+}
+
+func (m *planModel) expandCurrent(expanded bool) {
+	node := m.currentNode()
+	if node == nil || len(node.Children) == 0 {
+		return
+	}
+	node.Expanded = expanded
+	m.rebuildVisible()
+}
+
+func (m *planModel) setExpandedUnder(node *planNode, expanded bool) {
+	if node == nil {
+		return
+	}
+	node.Expanded = expanded
+	for _, c := range node.Children {
+		m.setExpandedUnder(c, expanded)
+	}
+}
+
+func (m planModel) View() string {
+	var b strings.Builder
+	inner := m.width
+	if inner <= 0 {
+		inner = 80
+	}
+	writeHeader(&b, inner, "PLAN TREE")
+	if m.searching {
+		writeWrapped(&b, filterStyle, fmt.Sprintf("SEARCH: %s", m.search), inner)
+	} else {
+		writeWrapped(&b, noteStyle, "enter/l expand · h collapse · E/C expand-collapse-all · / search · y copy path · q quit", inner)
+	}
+	b.WriteString("\n")
+
+	height := m.height
+	available := height - 3
+	if available < 1 {
+		available = len(m.visible)
+	}
+	start, end := clampSlice(m.offset, available, len(m.visible))
+	if m.cursor < start {
+		start = m.cursor
+	}
+	if m.cursor >= end {
+		start = m.cursor - available + 1
+		if start < 0 {
+			start = 0
+		}
+		end = start + available
+		if end > len(m.visible) {
+			end = len(m.visible)
+		}
+	}
+	for i := start; i < end; i++ {
+		b.WriteString(m.renderRow(i) + "\n")
+	}
+	if m.note != "" {
+		b.WriteString("\n")
+		writeWrapped(&b, noteStyle, m.note, inner)
+	}
+	return b.String()
+}
+
+func (m planModel) renderRow(i int) string {
+	node := m.visible[i]
+	depth := strings.Count(node.Path, ".") + strings.Count(node.Path, "[")
+	indent := strings.Repeat("  ", depth)
+
+	marker := " "
+	if len(node.Children) > 0 {
+		if node.Expanded {
+			marker = "-"
+		} else {
+			marker = "+"
+		}
+	}
+	cursor := " "
+	if i == m.cursor {
+		cursor = ">"
+	}
+
+	label := node.Key
+	if node.Kind == "scalar" {
+		value := node.Value
+		switch {
+		case node.IsReplace:
+			value = planReplaceStyle.Render(value)
+		case strings.Contains(node.Path, ".change.actions["):
+			value = styledPlanValue(value)
+		default:
+			value = planValueStyle.Render(value)
+		}
+		label = fmt.Sprintf("%s: %s", node.Key, value)
+	} else {
+		label = planKeyStyle.Render(fmt.Sprintf("%s (%d)", node.Key, len(node.Children)))
+	}
+
+	line := fmt.Sprintf("%s %s%s %s", cursor, indent, marker, label)
+	if i == m.cursor {
+		line = activeStyle.Render(line)
+	}
+	return line
+}
+
+// styledPlanValue colors resource_changes[*].change.actions entries:
+// create=green, update=yellow, delete=red. A replace (a delete+create
+// pair) is styled separately in renderRow via IsReplace, since the two
+// actions decode as sibling scalars rather than one joined value.
+func styledPlanValue(value string) string {
+	switch strings.Trim(value, `"`) {
+	case "create":
+		return planCreateStyle.Render(value)
+	case "update":
+		return planUpdateStyle.Render(value)
+	case "delete":
+		return planDeleteStyle.Render(value)
+	default:
+		return planValueStyle.Render(value)
+	}
+}
+
+// copyToClipboard shells out to whatever clipboard tool is available on the
+// platform, mirroring how the rest of tfz delegates to the `terraform`
+// binary rather than vendoring a library for something the OS already does.
+func copyToClipboard(text string) error {
+	candidates := [][]string{
+		{"pbcopy"},
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	for _, args := range candidates {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("no clipboard tool found (tried pbcopy, wl-copy, xclip, xsel)")
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const (
+	tfExt     = ".tf"
+	tfJSONExt = ".tf.json"
+)
+
+// fileScan is one .tf file's worth of target addresses and the source
+// ranges they were parsed from, cached by mtime so re-opening tfz in the
+// same directory doesn't re-walk the AST of files that haven't changed.
+type fileScan struct {
+	modTime time.Time
+	addrs   []string
+	sources map[string]targetSource
+	modules []moduleRef
+}
+
+// moduleRef is a module block's name and its (literal, unresolved) source
+// attribute, collected so findTargets can recurse into local child modules
+// and build their compound module.a.module.b.<addr> addresses.
+type moduleRef struct {
+	name   string
+	source string
+}
+
+var (
+	scanCacheMu sync.Mutex
+	scanCache   = map[string]fileScan{}
+)
+
+// findTargets walks dir and every local child module reachable from it,
+// and returns every resource, data source, and module address declared
+// there, sorted and deduplicated, alongside the source location each
+// address was parsed from. A file that fails to parse (a WIP edit, a file
+// not yet `terraform fmt`'d) is skipped rather than aborting the whole
+// scan; it's reported back in warnings so the caller can surface it
+// without losing the targets from every other file in dir.
+func findTargets(dir string) ([]string, map[string]targetSource, []string, error) {
+	seen := map[string]bool{}
+	sources := map[string]targetSource{}
+	visited := map[string]bool{}
+	var targets []string
+	var warnings []string
+
+	if err := collectTargets(dir, "", visited, seen, sources, &targets, &warnings); err != nil {
+		return nil, nil, nil, err
+	}
+
+	sort.Strings(targets)
+	return targets, sources, warnings, nil
+}
+
+// collectTargets scans dir (non-recursively, matching Terraform's own
+// single-directory module convention) and prefixes every address it finds
+// with prefix, which is "" at the root and "module.a.module.b." once
+// recursion has followed local module blocks down to b. A module block
+// whose source attribute is a literal relative path ("./..", "../..") is
+// followed into that child directory with one more "module.<name>."
+// segment appended to prefix, so a resource three modules deep comes back
+// as module.a.module.b.module.c.aws_instance.web, matching how Terraform
+// itself addresses it. visited guards against a module source cycle by
+// absolute path.
+func collectTargets(dir, prefix string, visited, seen map[string]bool, sources map[string]targetSource, targets *[]string, warnings *[]string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", dir, err)
+	}
+	if visited[abs] {
+		return nil
+	}
+	// visited holds only the directories on the current recursion chain, so
+	// two module blocks sharing one source directory are both scanned.
+	visited[abs] = true
+	defer delete(visited, abs)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isTerraformFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		addrs, fileSources, modules, err := scanFileCached(path)
+		if err != nil {
+			*warnings = append(*warnings, fmt.Sprintf("skipping %s: %v", path, err))
+			continue
+		}
+		for _, addr := range addrs {
+			full := prefix + addr
+			if seen[full] {
+				continue
+			}
+			seen[full] = true
+			*targets = append(*targets, full)
+			sources[full] = fileSources[addr]
+		}
+		for _, mod := range modules {
+			childDir, ok := resolveLocalModuleSource(dir, mod.source)
+			if !ok {
+				continue
+			}
+			childPrefix := prefix + "module." + mod.name + "."
+			if err := collectTargets(childDir, childPrefix, visited, seen, sources, targets, warnings); err != nil {
+				*warnings = append(*warnings, fmt.Sprintf("module %q: %v", childPrefix, err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveLocalModuleSource reports whether source is a local module
+// reference per Terraform's own rule (it starts with "./", "../", or is
+// an absolute path), and if so resolves it relative to dir. A registry
+// address, git URL, or any other remote source isn't on disk for tfz to
+// scan, so it's left unresolved.
+func resolveLocalModuleSource(dir, source string) (string, bool) {
+	if source == "" {
+		return "", false
+	}
+	if filepath.IsAbs(source) {
+		return source, true
+	}
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		return filepath.Join(dir, source), true
+	}
+	return "", false
+}
+
+func isTerraformFile(name string) bool {
+	return strings.HasSuffix(name, tfJSONExt) || strings.HasSuffix(name, tfExt)
+}
+
+// scanFileCached returns the cached scan for path if its mtime hasn't
+// changed since the last scan, and otherwise reparses it.
+func scanFileCached(path string) ([]string, map[string]targetSource, []moduleRef, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	scanCacheMu.Lock()
+	cached, ok := scanCache[path]
+	scanCacheMu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.addrs, cached.sources, cached.modules, nil
+	}
+
+	addrs, sources, modules, err := scanFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	scanCacheMu.Lock()
+	scanCache[path] = fileScan{modTime: info.ModTime(), addrs: addrs, sources: sources, modules: modules}
+	scanCacheMu.Unlock()
+	return addrs, sources, modules, nil
+}
+
+// scanBlockSchema is the resource/data/module block shapes scanFile looks
+// for in a .tf.json file. PartialContent (not Content) is deliberate: a
+// real Terraform file has plenty of other top-level blocks (variable,
+// output, provider, terraform, locals, ...) that aren't targets and
+// shouldn't turn into "extraneous property" errors.
+var scanBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+	},
+}
+
+// scanFile parses a single .tf (or .tf.json) file and returns every
+// resource/data/module address it declares, plus any module blocks found
+// (for findTargets to recurse into). .tf files are walked with the
+// hclsyntax AST directly so the preview pane gets exact source ranges;
+// .tf.json files go through the schema-driven hcl.Body API instead, since
+// a JSON body isn't an *hclsyntax.Body and carries no comparable range
+// info to offer the preview pane anyway.
+func scanFile(path string) ([]string, map[string]targetSource, []moduleRef, error) {
+	parser := hclparse.NewParser()
+
+	var (
+		file *hcl.File
+		diag hcl.Diagnostics
+	)
+	if strings.HasSuffix(path, tfJSONExt) {
+		file, diag = parser.ParseJSONFile(path)
+	} else {
+		file, diag = parser.ParseHCLFile(path)
+	}
+	if diag.HasErrors() {
+		return nil, nil, nil, diag
+	}
+
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		return scanHCLSyntaxBody(path, body)
+	}
+	return scanJSONBody(path, file.Body)
+}
+
+// scanHCLSyntaxBody walks a parsed .tf file's AST directly, so each
+// address comes with the exact source lines the preview pane jumps to.
+func scanHCLSyntaxBody(path string, body *hclsyntax.Body) ([]string, map[string]targetSource, []moduleRef, error) {
+	sources := map[string]targetSource{}
+	var addrs []string
+	var modules []moduleRef
+
+	for _, block := range body.Blocks {
+		base, ok := blockAddress(block.Type, block.Labels)
+		if !ok {
+			continue
+		}
+		var countExpr, forEachExpr hcl.Expression
+		if attr, ok := block.Body.Attributes["count"]; ok {
+			countExpr = attr.Expr
+		}
+		if attr, ok := block.Body.Attributes["for_each"]; ok {
+			forEachExpr = attr.Expr
+		}
+		src := targetSource{
+			Path:      path,
+			StartLine: block.TypeRange.Start.Line,
+			EndLine:   block.CloseBraceRange.End.Line,
+		}
+		for _, addr := range expandAddresses(base, countExpr, forEachExpr) {
+			addrs = append(addrs, addr)
+			sources[addr] = src
+		}
+		if block.Type == "module" {
+			if attr, ok := block.Body.Attributes["source"]; ok {
+				if source, ok := literalStringValue(attr.Expr); ok {
+					modules = append(modules, moduleRef{name: block.Labels[0], source: source})
+				}
+			}
+		}
+	}
+
+	return addrs, sources, modules, nil
+}
+
+// scanJSONBody decodes a .tf.json file's resource/data/module blocks via
+// the schema-driven hcl.Body API instead of a *hclsyntax.Body type
+// assertion, which always fails for JSON and previously made every
+// .tf.json file scan to zero targets.
+func scanJSONBody(path string, body hcl.Body) ([]string, map[string]targetSource, []moduleRef, error) {
+	content, _, diag := body.PartialContent(scanBlockSchema)
+	if diag.HasErrors() {
+		return nil, nil, nil, diag
+	}
+
+	sources := map[string]targetSource{}
+	var addrs []string
+	var modules []moduleRef
+
+	for _, block := range content.Blocks {
+		base, ok := blockAddress(block.Type, block.Labels)
+		if !ok {
+			continue
+		}
+		// A JSON body's attributes (including count/for_each/source) live
+		// on the block's own Body; diagnostics here just mean "no literal
+		// attributes to read", same as a hclsyntax block with none.
+		attrs, _ := block.Body.JustAttributes()
+		var countExpr, forEachExpr hcl.Expression
+		if attr, ok := attrs["count"]; ok {
+			countExpr = attr.Expr
+		}
+		if attr, ok := attrs["for_each"]; ok {
+			forEachExpr = attr.Expr
+		}
+		src := targetSource{Path: path}
+		for _, addr := range expandAddresses(base, countExpr, forEachExpr) {
+			addrs = append(addrs, addr)
+			sources[addr] = src
+		}
+		if block.Type == "module" {
+			if attr, ok := attrs["source"]; ok {
+				if source, ok := literalStringValue(attr.Expr); ok {
+					modules = append(modules, moduleRef{name: block.Labels[0], source: source})
+				}
+			}
+		}
+	}
+
+	return addrs, sources, modules, nil
+}
+
+// literalStringValue evaluates expr with no variables/functions available
+// and returns its value as a string, for attributes (like a module's
+// source) that only make sense to follow when they're a plain literal.
+func literalStringValue(expr hcl.Expression) (string, bool) {
+	val, diag := expr.Value(nil)
+	if diag.HasErrors() || val.IsNull() || val.Type() != cty.String {
+		return "", false
+	}
+	return val.AsString(), true
+}
+
+// blockAddress returns the unindexed target address for a block's type, in
+// Terraform's own addressing scheme: resources and data sources drop the
+// "resource"/"data" prefix for resources but keep it for data sources,
+// and module blocks address as module.<name>.
+func blockAddress(blockType string, labels []string) (string, bool) {
+	switch blockType {
+	case "resource":
+		if len(labels) != 2 {
+			return "", false
+		}
+		return labels[0] + "." + labels[1], true
+	case "data":
+		if len(labels) != 2 {
+			return "", false
+		}
+		return "data." + labels[0] + "." + labels[1], true
+	case "module":
+		if len(labels) != 1 {
+			return "", false
+		}
+		return "module." + labels[0], true
+	default:
+		return "", false
+	}
+}
+
+// forEachEvalContext supplies the handful of built-ins that show up in the
+// overwhelming majority of for_each expressions tfz encounters in the
+// wild — toset(...)/tolist(...)/tomap(...) wrapping an inline literal —
+// so expandAddresses can evaluate them the same way `attr.Expr.Value(nil)`
+// already handles a bare literal map. Anything that calls a function
+// outside this tiny set (or references a variable/resource) still fails
+// to evaluate and falls back to the unexpanded base address, same as
+// today.
+var forEachEvalContext = &hcl.EvalContext{
+	Functions: map[string]function.Function{
+		"toset":  collectionConvFunc(cty.Set(cty.DynamicPseudoType)),
+		"tolist": collectionConvFunc(cty.List(cty.DynamicPseudoType)),
+		"tomap":  collectionConvFunc(cty.Map(cty.DynamicPseudoType)),
+	},
+}
+
+// collectionConvFunc builds a single-argument function that converts its
+// argument to want, the same way Terraform's own toset/tolist/tomap
+// built-ins defer straight to cty's conversion rules.
+func collectionConvFunc(want cty.Type) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{
+			Name:             "v",
+			Type:             cty.DynamicPseudoType,
+			AllowDynamicType: true,
+		}},
+		Type: function.StaticReturnType(want),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return convert.Convert(args[0], want)
+		},
+	})
+}
+
+// expandAddresses expands base into one address per count/for_each
+// instance when countExpr/forEachExpr is a literal tfz can evaluate
+// without a full Terraform EvalContext (a plain number, or a
+// map/object/set of literal strings, optionally wrapped in toset/tomap).
+// A tolist(...) result is keyed by index, not by string, so it is left
+// unexpanded under base. Anything that depends on a variable or another
+// resource is left unexpanded under base, since tfz only scans files — it
+// never runs a real plan to resolve those values. Either expression may be
+// nil if the block doesn't set that attribute.
+func expandAddresses(base string, countExpr, forEachExpr hcl.Expression) []string {
+	if countExpr != nil {
+		val, diag := countExpr.Value(nil)
+		if !diag.HasErrors() && val.Type() == cty.Number {
+			n, _ := val.AsBigFloat().Int64()
+			addrs := make([]string, 0, n)
+			for i := int64(0); i < n; i++ {
+				addrs = append(addrs, fmt.Sprintf("%s[%d]", base, i))
+			}
+			if len(addrs) > 0 {
+				return addrs
+			}
+		}
+		return []string{base}
+	}
+
+	if forEachExpr != nil {
+		val, diag := forEachExpr.Value(forEachEvalContext)
+		if !diag.HasErrors() && !val.IsNull() && val.CanIterateElements() {
+			var addrs []string
+			for it := val.ElementIterator(); it.Next(); {
+				key, _ := it.Element()
+				if key.Type() != cty.String {
+					continue
+				}
+				addrs = append(addrs, fmt.Sprintf("%s[%q]", base, key.AsString()))
+			}
+			if len(addrs) > 0 {
+				return addrs
+			}
+		}
+		return []string{base}
+	}
+
+	return []string{base}
+}